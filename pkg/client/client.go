@@ -17,9 +17,8 @@ limitations under the License.
 package client
 
 import (
-	"bytes"
 	"crypto/tls"
-	"encoding/json"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,6 +26,9 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+
+	"code.google.com/p/go.net/context"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
@@ -54,6 +56,43 @@ type ClientInterface interface {
 type AuthInfo struct {
 	User     string
 	Password string
+
+	// Token, if set, is sent as an "Authorization: Bearer" header instead of
+	// HTTP Basic auth. TokenFile, if set, is re-read to pick up a refreshed
+	// token (e.g. a projected service account token) whenever a request comes
+	// back 401. Token is read and written from multiple goroutines (watch
+	// loops and the methods they're called alongside), so access goes
+	// through tokenMu.
+	Token     string
+	TokenFile string
+	tokenMu   sync.RWMutex
+
+	// CAData is the PEM-encoded certificate authority used to verify the
+	// server's certificate. If empty, the client falls back to skipping
+	// verification.
+	CAData []byte
+}
+
+// token returns the current bearer token, safe for concurrent use with refreshToken.
+func (auth *AuthInfo) token() string {
+	auth.tokenMu.RLock()
+	defer auth.tokenMu.RUnlock()
+	return auth.Token
+}
+
+// refreshToken re-reads Token from TokenFile. It is a no-op if TokenFile is empty.
+func (auth *AuthInfo) refreshToken() error {
+	if auth.TokenFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(auth.TokenFile)
+	if err != nil {
+		return err
+	}
+	auth.tokenMu.Lock()
+	auth.Token = string(data)
+	auth.tokenMu.Unlock()
+	return nil
 }
 
 // Client is the actual implementation of a Kubernetes client.
@@ -62,41 +101,63 @@ type Client struct {
 	host       string
 	auth       *AuthInfo
 	httpClient *http.Client
+
+	// namespace is the namespace this client was configured for, e.g. by
+	// NewInCluster reading the service account directory. It is empty for
+	// clients built with New.
+	namespace string
+}
+
+// newTLSConfig builds the TLS config used to talk to the API server. If auth
+// supplies a CA certificate, it is parsed into a pool and the server
+// certificate is verified against it; otherwise verification is skipped, as
+// before.
+func newTLSConfig(auth *AuthInfo) (*tls.Config, error) {
+	if auth == nil || len(auth.CAData) == 0 {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(auth.CAData); !ok {
+		return nil, fmt.Errorf("client: failed to parse CA certificate")
+	}
+	return &tls.Config{RootCAs: pool}, nil
 }
 
 // Create a new client object.
-func New(host string, auth *AuthInfo) *Client {
+func New(host string, auth *AuthInfo) (*Client, error) {
+	tlsConfig, err := newTLSConfig(auth)
+	if err != nil {
+		return nil, err
+	}
 	return &Client{
 		auth: auth,
 		host: host,
 		httpClient: &http.Client{
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
+				TLSClientConfig: tlsConfig,
 			},
 		},
-	}
+	}, nil
 }
 
 // Execute a request, adds authentication (if auth != nil), and HTTPS cert ignoring.
+// doRequest runs with context.Background(); use doRequestWithContext directly
+// to bound or cancel the request.
 func (c *Client) doRequest(request *http.Request) ([]byte, error) {
-	if c.auth != nil {
-		request.SetBasicAuth(c.auth.User, c.auth.Password)
-	}
-	response, err := c.httpClient.Do(request)
-	if err != nil {
-		return []byte{}, err
-	}
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return body, err
+	return c.doRequestWithContext(context.Background(), request)
+}
+
+// authenticate sets the request's auth header, preferring a bearer token
+// over HTTP Basic auth when both are present.
+func (c *Client) authenticate(request *http.Request) {
+	if c.auth == nil {
+		return
 	}
-	if response.StatusCode < http.StatusOK || response.StatusCode > http.StatusPartialContent {
-		return nil, fmt.Errorf("request [%#v] failed (%d) %s: %s", request, response.StatusCode, response.Status, string(body))
+	if token := c.auth.token(); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return
 	}
-	return body, err
+	request.SetBasicAuth(c.auth.User, c.auth.Password)
 }
 
 // Underlying base implementation of performing a request.
@@ -105,10 +166,21 @@ func (c *Client) doRequest(request *http.Request) ([]byte, error) {
 // requestBody is the body of the request. Can be nil.
 // target the interface to marshal the JSON response into.  Can be nil.
 func (c *Client) rawRequest(method, path string, requestBody io.Reader, target interface{}) ([]byte, error) {
+	return c.rawRequestWithContext(context.Background(), method, path, requestBody, target)
+}
+
+// rawRequestWithContentType is rawRequest, but sets the request's
+// Content-Type explicitly rather than leaving it unset. Patch requests need
+// this since each PatchType maps to a different media type the server uses
+// to decide how to interpret the body.
+func (c *Client) rawRequestWithContentType(method, path, contentType string, requestBody io.Reader, target interface{}) ([]byte, error) {
 	request, err := http.NewRequest(method, c.makeURL(path), requestBody)
 	if err != nil {
 		return []byte{}, err
 	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
 	body, err := c.doRequest(request)
 	if err != nil {
 		return body, err
@@ -158,108 +230,83 @@ func DecodeSelector(selector string) map[string]string {
 
 // ListPods takes a selector, and returns the list of pods that match that selector
 func (client Client) ListPods(selector map[string]string) (api.PodList, error) {
-	path := "pods"
-	if selector != nil && len(selector) > 0 {
-		path += "?labels=" + EncodeSelector(selector)
-	}
 	var result api.PodList
-	_, err := client.rawRequest("GET", path, nil, &result)
+	err := client.Resource("pods").listInto(selector, &result)
 	return result, err
 }
 
 // GetPod takes the name of the pod, and returns the corresponding Pod object, and an error if it occurs
 func (client Client) GetPod(name string) (api.Pod, error) {
 	var result api.Pod
-	_, err := client.rawRequest("GET", "pods/"+name, nil, &result)
+	err := client.Resource("pods").getInto(name, &result)
 	return result, err
 }
 
 // DeletePod takes the name of the pod, and returns an error if one occurs
 func (client Client) DeletePod(name string) error {
-	_, err := client.rawRequest("DELETE", "pods/"+name, nil, nil)
-	return err
+	return client.Resource("pods").deleteImpl(name)
 }
 
 // CreatePod takes the representation of a pod.  Returns the server's representation of the pod, and an error, if it occurs
 func (client Client) CreatePod(pod api.Pod) (api.Pod, error) {
 	var result api.Pod
-	body, err := json.Marshal(pod)
-	if err == nil {
-		_, err = client.rawRequest("POST", "pods", bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("pods").createInto(pod, &result)
 	return result, err
 }
 
 // UpdatePod takes the representation of a pod to update.  Returns the server's representation of the pod, and an error, if it occurs
 func (client Client) UpdatePod(pod api.Pod) (api.Pod, error) {
 	var result api.Pod
-	body, err := json.Marshal(pod)
-	if err == nil {
-		_, err = client.rawRequest("PUT", "pods/"+pod.ID, bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("pods").updateInto(pod.ID, pod, &result)
 	return result, err
 }
 
 // GetReplicationController returns information about a particular replication controller
 func (client Client) GetReplicationController(name string) (api.ReplicationController, error) {
 	var result api.ReplicationController
-	_, err := client.rawRequest("GET", "replicationControllers/"+name, nil, &result)
+	err := client.Resource("replicationControllers").getInto(name, &result)
 	return result, err
 }
 
 // CreateReplicationController creates a new replication controller
 func (client Client) CreateReplicationController(controller api.ReplicationController) (api.ReplicationController, error) {
 	var result api.ReplicationController
-	body, err := json.Marshal(controller)
-	if err == nil {
-		_, err = client.rawRequest("POST", "replicationControllers", bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("replicationControllers").createInto(controller, &result)
 	return result, err
 }
 
 // UpdateReplicationController updates an existing replication controller
 func (client Client) UpdateReplicationController(controller api.ReplicationController) (api.ReplicationController, error) {
 	var result api.ReplicationController
-	body, err := json.Marshal(controller)
-	if err == nil {
-		_, err = client.rawRequest("PUT", "replicationControllers/"+controller.ID, bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("replicationControllers").updateInto(controller.ID, controller, &result)
 	return result, err
 }
 
 func (client Client) DeleteReplicationController(name string) error {
-	_, err := client.rawRequest("DELETE", "replicationControllers/"+name, nil, nil)
-	return err
+	return client.Resource("replicationControllers").deleteImpl(name)
 }
 
 // GetReplicationController returns information about a particular replication controller
 func (client Client) GetService(name string) (api.Service, error) {
 	var result api.Service
-	_, err := client.rawRequest("GET", "services/"+name, nil, &result)
+	err := client.Resource("services").getInto(name, &result)
 	return result, err
 }
 
 // CreateReplicationController creates a new replication controller
 func (client Client) CreateService(svc api.Service) (api.Service, error) {
 	var result api.Service
-	body, err := json.Marshal(svc)
-	if err == nil {
-		_, err = client.rawRequest("POST", "services", bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("services").createInto(svc, &result)
 	return result, err
 }
 
 // UpdateReplicationController updates an existing replication controller
 func (client Client) UpdateService(svc api.Service) (api.Service, error) {
 	var result api.Service
-	body, err := json.Marshal(svc)
-	if err == nil {
-		_, err = client.rawRequest("PUT", "services/"+svc.ID, bytes.NewBuffer(body), &result)
-	}
+	err := client.Resource("services").updateInto(svc.ID, svc, &result)
 	return result, err
 }
 
 func (client Client) DeleteService(name string) error {
-	_, err := client.rawRequest("DELETE", "services/"+name, nil, nil)
-	return err
+	return client.Resource("services").deleteImpl(name)
 }
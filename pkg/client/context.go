@@ -0,0 +1,240 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"code.google.com/p/go.net/context"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// canceler is implemented by *http.Transport (and anything else that wants
+// to support request cancellation). It lets doRequestWithContext abort an
+// in-flight request when its context is done, the same way
+// code.google.com/p/go.net/context/ctxhttp does.
+type canceler interface {
+	CancelRequest(*http.Request)
+}
+
+// doRequestWithContext is doRequest, but aborts the request (and the read of
+// response.Body) as soon as ctx is done.
+func (c *Client) doRequestWithContext(ctx context.Context, request *http.Request) ([]byte, error) {
+	c.authenticate(request)
+	response, err := c.doWithContext(ctx, request)
+	if err != nil {
+		return []byte{}, wrapTransportError(ctx, request, err)
+	}
+	if response.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.TokenFile != "" {
+		response.Body.Close()
+		if refreshErr := c.auth.refreshToken(); refreshErr != nil {
+			return []byte{}, fmt.Errorf("client: failed to refresh token after 401: %v", refreshErr)
+		}
+		if request.GetBody != nil {
+			freshBody, bodyErr := request.GetBody()
+			if bodyErr != nil {
+				return []byte{}, bodyErr
+			}
+			request.Body = freshBody
+		}
+		c.authenticate(request)
+		response, err = c.doWithContext(ctx, request)
+		if err != nil {
+			return []byte{}, wrapTransportError(ctx, request, err)
+		}
+	}
+	defer response.Body.Close()
+
+	body, err := readAllWithContext(ctx, response.Body)
+	if err != nil {
+		return body, err
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode > http.StatusPartialContent {
+		if status, ok := decodeStatus(body); ok {
+			return nil, &StatusError{Status: status}
+		}
+		return nil, fmt.Errorf("request [%#v] failed (%d) %s: %s", request, response.StatusCode, response.Status, string(body))
+	}
+	return body, err
+}
+
+// wrapTransportError leaves a context cancellation/deadline error as-is (the
+// caller asked for it), and wraps any other failure reaching doWithContext
+// as a *TransportError so it's distinguishable from a *StatusError.
+func wrapTransportError(ctx context.Context, request *http.Request, err error) error {
+	if cancelErr := ctx.Err(); cancelErr != nil && err == cancelErr {
+		return err
+	}
+	return &TransportError{Request: request, Err: err}
+}
+
+// doWithContext issues request on c.httpClient and returns as soon as either
+// the response arrives or ctx is done, canceling the request in the latter
+// case.
+func (c *Client) doWithContext(ctx context.Context, request *http.Request) (*http.Response, error) {
+	type result struct {
+		response *http.Response
+		err      error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		response, err := c.httpClient.Do(request)
+		resultCh <- result{response, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if cancelable, ok := c.httpClient.Transport.(canceler); ok {
+			cancelable.CancelRequest(request)
+		}
+		<-resultCh
+		return nil, ctx.Err()
+	case res := <-resultCh:
+		return res.response, res.err
+	}
+}
+
+// readAllWithContext reads body to completion, but gives up as soon as ctx
+// is done rather than blocking on a slow or stalled server.
+func readAllWithContext(ctx context.Context, body io.Reader) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		data, err := ioutil.ReadAll(body)
+		resultCh <- result{data, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultCh:
+		return res.data, res.err
+	}
+}
+
+// rawRequestWithContext is rawRequest, bounded by ctx.
+func (c *Client) rawRequestWithContext(ctx context.Context, method, path string, requestBody io.Reader, target interface{}) ([]byte, error) {
+	request, err := http.NewRequest(method, c.makeURL(path), requestBody)
+	if err != nil {
+		return []byte{}, err
+	}
+	body, err := c.doRequestWithContext(ctx, request)
+	if err != nil {
+		return body, err
+	}
+	if target != nil {
+		err = api.DecodeInto(body, target)
+	}
+	if err != nil {
+		log.Printf("Failed to parse: %s\n", string(body))
+		// FIXME: no need to return err here?
+	}
+	return body, err
+}
+
+// ListPodsWithContext is ListPods, bounded by ctx.
+func (c *Client) ListPodsWithContext(ctx context.Context, selector map[string]string) (api.PodList, error) {
+	var result api.PodList
+	err := c.Resource("pods").listIntoContext(ctx, selector, &result)
+	return result, err
+}
+
+// GetPodWithContext is GetPod, bounded by ctx.
+func (c *Client) GetPodWithContext(ctx context.Context, name string) (api.Pod, error) {
+	var result api.Pod
+	err := c.Resource("pods").getIntoContext(ctx, name, &result)
+	return result, err
+}
+
+// DeletePodWithContext is DeletePod, bounded by ctx.
+func (c *Client) DeletePodWithContext(ctx context.Context, name string) error {
+	return c.Resource("pods").deleteContext(ctx, name)
+}
+
+// CreatePodWithContext is CreatePod, bounded by ctx.
+func (c *Client) CreatePodWithContext(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	var result api.Pod
+	err := c.Resource("pods").createIntoContext(ctx, pod, &result)
+	return result, err
+}
+
+// UpdatePodWithContext is UpdatePod, bounded by ctx.
+func (c *Client) UpdatePodWithContext(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	var result api.Pod
+	err := c.Resource("pods").updateIntoContext(ctx, pod.ID, pod, &result)
+	return result, err
+}
+
+// GetReplicationControllerWithContext is GetReplicationController, bounded by ctx.
+func (c *Client) GetReplicationControllerWithContext(ctx context.Context, name string) (api.ReplicationController, error) {
+	var result api.ReplicationController
+	err := c.Resource("replicationControllers").getIntoContext(ctx, name, &result)
+	return result, err
+}
+
+// CreateReplicationControllerWithContext is CreateReplicationController, bounded by ctx.
+func (c *Client) CreateReplicationControllerWithContext(ctx context.Context, controller api.ReplicationController) (api.ReplicationController, error) {
+	var result api.ReplicationController
+	err := c.Resource("replicationControllers").createIntoContext(ctx, controller, &result)
+	return result, err
+}
+
+// UpdateReplicationControllerWithContext is UpdateReplicationController, bounded by ctx.
+func (c *Client) UpdateReplicationControllerWithContext(ctx context.Context, controller api.ReplicationController) (api.ReplicationController, error) {
+	var result api.ReplicationController
+	err := c.Resource("replicationControllers").updateIntoContext(ctx, controller.ID, controller, &result)
+	return result, err
+}
+
+// DeleteReplicationControllerWithContext is DeleteReplicationController, bounded by ctx.
+func (c *Client) DeleteReplicationControllerWithContext(ctx context.Context, name string) error {
+	return c.Resource("replicationControllers").deleteContext(ctx, name)
+}
+
+// GetServiceWithContext is GetService, bounded by ctx.
+func (c *Client) GetServiceWithContext(ctx context.Context, name string) (api.Service, error) {
+	var result api.Service
+	err := c.Resource("services").getIntoContext(ctx, name, &result)
+	return result, err
+}
+
+// CreateServiceWithContext is CreateService, bounded by ctx.
+func (c *Client) CreateServiceWithContext(ctx context.Context, svc api.Service) (api.Service, error) {
+	var result api.Service
+	err := c.Resource("services").createIntoContext(ctx, svc, &result)
+	return result, err
+}
+
+// UpdateServiceWithContext is UpdateService, bounded by ctx.
+func (c *Client) UpdateServiceWithContext(ctx context.Context, svc api.Service) (api.Service, error) {
+	var result api.Service
+	err := c.Resource("services").updateIntoContext(ctx, svc.ID, svc, &result)
+	return result, err
+}
+
+// DeleteServiceWithContext is DeleteService, bounded by ctx.
+func (c *Client) DeleteServiceWithContext(ctx context.Context, name string) error {
+	return c.Resource("services").deleteContext(ctx, name)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"code.google.com/p/go.net/context"
+)
+
+// TestDoRequestRetriesBodyAfterTokenRefresh guards against a regression
+// where a 401 retry re-sent a POST/PUT with the original request's Body
+// already drained by the failed first attempt, silently shipping an empty
+// body on the retry.
+func TestDoRequestRetriesBodyAfterTokenRefresh(t *testing.T) {
+	const wantBody = `{"id":"pod-1"}`
+
+	var attempts int
+	var gotBodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBodies = append(gotBodies, string(body))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tokenFile, cleanup := writeTempTokenFile(t, "refreshed-token")
+	defer cleanup()
+
+	c := &Client{
+		host:       server.URL,
+		auth:       &AuthInfo{Token: "stale-token", TokenFile: tokenFile},
+		httpClient: server.Client(),
+	}
+
+	request, err := http.NewRequest("POST", server.URL+"/pods", bytes.NewBufferString(wantBody))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	if _, err := c.doRequestWithContext(context.Background(), request); err != nil {
+		t.Fatalf("doRequestWithContext: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("got %d attempts, want 2 (one 401, one retry)", attempts)
+	}
+	for i, got := range gotBodies {
+		if got != wantBody {
+			t.Errorf("attempt %d body = %q, want %q", i+1, got, wantBody)
+		}
+	}
+}
+
+// TestDoRequestReturnsErrorWhenTokenRefreshFails guards against a regression
+// where a failed refreshToken() fell through to closing and reading the
+// already-closed first 401 response, surfacing a confusing "read on closed
+// response body" instead of the actual refresh error.
+func TestDoRequestReturnsErrorWhenTokenRefreshFails(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := &Client{
+		host: server.URL,
+		// TokenFile points at a path that doesn't exist, so refreshToken fails.
+		auth:       &AuthInfo{Token: "stale-token", TokenFile: "/nonexistent/token"},
+		httpClient: server.Client(),
+	}
+
+	request, err := http.NewRequest("GET", server.URL+"/pods/foo", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	_, err = c.doRequestWithContext(context.Background(), request)
+	if err == nil {
+		t.Fatal("doRequestWithContext: got nil error, want the refresh failure")
+	}
+	if attempts != 1 {
+		t.Fatalf("got %d attempts, want 1 (no retry once refresh fails)", attempts)
+	}
+}
+
+func writeTempTokenFile(t *testing.T, contents string) (path string, cleanup func()) {
+	t.Helper()
+	f, err := ioutil.TempFile("", "kube-token")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	if _, err := f.WriteString(contents); err != nil {
+		f.Close()
+		t.Fatalf("WriteString: %v", err)
+	}
+	f.Close()
+	return f.Name(), func() { os.Remove(f.Name()) }
+}
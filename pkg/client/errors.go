@@ -0,0 +1,112 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// Well-known api.Status.Reason values the server uses, so IsNotFound and
+// friends don't have to string-match Error().
+const (
+	StatusReasonNotFound      = "NotFound"
+	StatusReasonAlreadyExists = "AlreadyExists"
+	StatusReasonConflict      = "Conflict"
+	StatusReasonUnauthorized  = "Unauthorized"
+	StatusReasonServerTimeout = "ServerTimeout"
+)
+
+// StatusError wraps the api.Status the server returned for a well-formed
+// API error response, so callers can branch on Reason or Code instead of
+// string-matching Error(). doRequest returns a *StatusError whenever the
+// server's error body decodes into one.
+type StatusError struct {
+	Status api.Status
+}
+
+func (e *StatusError) Error() string {
+	return e.Status.Message
+}
+
+// TransportError wraps a failure that happened before the server had a
+// chance to respond at all (DNS, connection refused, timeout, ...). It is
+// distinguished from StatusError so callers can tell "the network is
+// broken, maybe retry" from "the API rejected the request, maybe don't".
+type TransportError struct {
+	Request *http.Request
+	Err     error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("transport error on %s %s: %v", e.Request.Method, e.Request.URL, e.Err)
+}
+
+// decodeStatus attempts to parse body as an api.Status. The second return
+// value is false if body doesn't look like a Status at all (e.g. the server
+// or an intermediate proxy returned plain text), in which case the caller
+// should fall back to a generic error.
+func decodeStatus(body []byte) (api.Status, bool) {
+	var status api.Status
+	if err := json.Unmarshal(body, &status); err != nil {
+		return api.Status{}, false
+	}
+	if status.Reason == "" && status.Message == "" && status.Code == 0 {
+		return api.Status{}, false
+	}
+	return status, true
+}
+
+func reasonForError(err error) string {
+	if statusErr, ok := err.(*StatusError); ok {
+		return statusErr.Status.Reason
+	}
+	return ""
+}
+
+// IsNotFound returns true if err is a StatusError reporting that the
+// requested object doesn't exist.
+func IsNotFound(err error) bool {
+	return reasonForError(err) == StatusReasonNotFound
+}
+
+// IsAlreadyExists returns true if err is a StatusError reporting that the
+// object a Create call tried to make already exists.
+func IsAlreadyExists(err error) bool {
+	return reasonForError(err) == StatusReasonAlreadyExists
+}
+
+// IsConflict returns true if err is a StatusError reporting a conflicting
+// concurrent modification; callers should re-read the object and retry.
+func IsConflict(err error) bool {
+	return reasonForError(err) == StatusReasonConflict
+}
+
+// IsUnauthorized returns true if err is a StatusError reporting that the
+// request's credentials were rejected.
+func IsUnauthorized(err error) bool {
+	return reasonForError(err) == StatusReasonUnauthorized
+}
+
+// IsServerTimeout returns true if err is a StatusError reporting that the
+// server timed out completing the request; it is usually safe to retry.
+func IsServerTimeout(err error) bool {
+	return reasonForError(err) == StatusReasonServerTimeout
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	serviceAccountDir       = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountTokenFile = serviceAccountDir + "/token"
+	serviceAccountCAFile    = serviceAccountDir + "/ca.crt"
+	serviceAccountNamespace = serviceAccountDir + "/namespace"
+	inClusterHostEnvName    = "KUBERNETES_SERVICE_HOST"
+	inClusterPortEnvName    = "KUBERNETES_SERVICE_PORT"
+)
+
+// NewInCluster returns a Client configured to talk to the API server from
+// inside a pod, using the credentials and CA certificate that Kubernetes
+// projects into every container's service account directory. It requires no
+// host or auth to be supplied by hand: the bearer token, namespace and CA are
+// all read from serviceAccountDir, and the token is refreshed from disk
+// whenever a request comes back 401 (projected service account tokens
+// expire and are rotated underneath a long-running process).
+func NewInCluster() (*Client, error) {
+	host, port := os.Getenv(inClusterHostEnvName), os.Getenv(inClusterPortEnvName)
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("unable to load in-cluster configuration, %s and %s must be defined", inClusterHostEnvName, inClusterPortEnvName)
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account token: %v", err)
+	}
+
+	ca, err := ioutil.ReadFile(serviceAccountCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account CA certificate: %v", err)
+	}
+
+	namespace, err := ioutil.ReadFile(serviceAccountNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account namespace: %v", err)
+	}
+
+	auth := &AuthInfo{
+		Token:     string(token),
+		TokenFile: serviceAccountTokenFile,
+		CAData:    ca,
+	}
+
+	c, err := New("https://"+host+":"+port, auth)
+	if err != nil {
+		return nil, err
+	}
+	c.namespace = string(namespace)
+	return c, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// PatchPod partially updates the named pod. Use MergePatchFrom to build data
+// for MergePatchType, or hand-write a JSON Patch / strategic-merge-patch
+// body for the other PatchTypes.
+func (client Client) PatchPod(name string, patchType PatchType, data []byte) (api.Pod, error) {
+	var result api.Pod
+	err := client.Resource("pods").patchInto(name, patchType, data, &result)
+	return result, err
+}
+
+// PatchService partially updates the named service.
+func (client Client) PatchService(name string, patchType PatchType, data []byte) (api.Service, error) {
+	var result api.Service
+	err := client.Resource("services").patchInto(name, patchType, data, &result)
+	return result, err
+}
+
+// PatchReplicationController partially updates the named replication controller.
+func (client Client) PatchReplicationController(name string, patchType PatchType, data []byte) (api.ReplicationController, error) {
+	var result api.ReplicationController
+	err := client.Resource("replicationControllers").patchInto(name, patchType, data, &result)
+	return result, err
+}
+
+// MergePatchFrom diffs original and modified (typically two api.Pod,
+// api.Service or api.ReplicationController values) into a JSON Merge Patch
+// body (RFC 7386) suitable for Patch*(..., MergePatchType, ...). This lets a
+// caller change one field (e.g. a controller's label) without resending the
+// whole object and racing a concurrent Update from another controller.
+//
+// Fields that changed or were added in modified are included; fields
+// present in original but missing from modified are set to nil so the
+// server removes them. Per RFC 7386, a list field that differs at all is
+// replaced wholesale rather than diffed element-by-element — this is not
+// suitable for StrategicMergePatchType, where the server instead merges
+// lists by patch-merge-key and a dropped element wouldn't be removed.
+func MergePatchFrom(original, modified interface{}) ([]byte, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	var originalMap, modifiedMap map[string]interface{}
+	if err := json.Unmarshal(originalJSON, &originalMap); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modifiedJSON, &modifiedMap); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(diffMaps(originalMap, modifiedMap))
+}
+
+// diffMaps returns the subset of modified that differs from original,
+// recursing into nested objects so sibling fields that didn't change are
+// left out of the patch entirely.
+func diffMaps(original, modified map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+
+	for key, modifiedValue := range modified {
+		originalValue, present := original[key]
+		if !present {
+			patch[key] = modifiedValue
+			continue
+		}
+
+		originalNested, originalIsMap := originalValue.(map[string]interface{})
+		modifiedNested, modifiedIsMap := modifiedValue.(map[string]interface{})
+		if originalIsMap && modifiedIsMap {
+			if nested := diffMaps(originalNested, modifiedNested); len(nested) > 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(originalValue, modifiedValue) {
+			patch[key] = modifiedValue
+		}
+	}
+
+	for key := range original {
+		if _, present := modified[key]; !present {
+			patch[key] = nil
+		}
+	}
+
+	return patch
+}
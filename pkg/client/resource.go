@@ -0,0 +1,193 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"code.google.com/p/go.net/context"
+)
+
+// ResourceClient talks to a single resource kind (e.g. "pods",
+// "replicationControllers", or any kind the server exposes that this
+// package has no typed methods for) using untyped JSON documents. The
+// typed ListPods/GetService/... methods on Client are themselves built on
+// top of a ResourceClient.
+type ResourceClient struct {
+	client *Client
+	kind   string
+}
+
+// Resource returns a ResourceClient for kind, the path segment the API
+// server expects (e.g. "pods"), for talking to resource types this package
+// has no typed methods for.
+func (c *Client) Resource(kind string) *ResourceClient {
+	return &ResourceClient{client: c, kind: kind}
+}
+
+func (r *ResourceClient) path(name string) string {
+	if name == "" {
+		return r.kind
+	}
+	return r.kind + "/" + name
+}
+
+func (r *ResourceClient) getInto(name string, target interface{}) error {
+	return r.getIntoContext(context.Background(), name, target)
+}
+
+func (r *ResourceClient) getIntoContext(ctx context.Context, name string, target interface{}) error {
+	_, err := r.client.rawRequestWithContext(ctx, "GET", r.path(name), nil, target)
+	return err
+}
+
+func (r *ResourceClient) listInto(selector map[string]string, target interface{}) error {
+	return r.listIntoContext(context.Background(), selector, target)
+}
+
+func (r *ResourceClient) listIntoContext(ctx context.Context, selector map[string]string, target interface{}) error {
+	path := r.path("")
+	if selector != nil && len(selector) > 0 {
+		path += "?labels=" + EncodeSelector(selector)
+	}
+	_, err := r.client.rawRequestWithContext(ctx, "GET", path, nil, target)
+	return err
+}
+
+func (r *ResourceClient) createInto(obj interface{}, target interface{}) error {
+	return r.createIntoContext(context.Background(), obj, target)
+}
+
+func (r *ResourceClient) createIntoContext(ctx context.Context, obj interface{}, target interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.rawRequestWithContext(ctx, "POST", r.path(""), bytes.NewBuffer(body), target)
+	return err
+}
+
+func (r *ResourceClient) updateInto(name string, obj interface{}, target interface{}) error {
+	return r.updateIntoContext(context.Background(), name, obj, target)
+}
+
+func (r *ResourceClient) updateIntoContext(ctx context.Context, name string, obj interface{}, target interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.rawRequestWithContext(ctx, "PUT", r.path(name), bytes.NewBuffer(body), target)
+	return err
+}
+
+func (r *ResourceClient) deleteImpl(name string) error {
+	return r.deleteContext(context.Background(), name)
+}
+
+func (r *ResourceClient) deleteContext(ctx context.Context, name string) error {
+	_, err := r.client.rawRequestWithContext(ctx, "DELETE", r.path(name), nil, nil)
+	return err
+}
+
+// Get returns the named object as a generic JSON document.
+func (r *ResourceClient) Get(name string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := r.getInto(name, &result)
+	return result, err
+}
+
+// List returns the objects matching selector as a generic JSON document.
+func (r *ResourceClient) List(selector map[string]string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := r.listInto(selector, &result)
+	return result, err
+}
+
+// Create creates obj and returns the server's representation.
+func (r *ResourceClient) Create(obj map[string]interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := r.createInto(obj, &result)
+	return result, err
+}
+
+// Update replaces the named object with obj and returns the server's representation.
+func (r *ResourceClient) Update(name string, obj map[string]interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := r.updateInto(name, obj, &result)
+	return result, err
+}
+
+// Delete deletes the named object.
+func (r *ResourceClient) Delete(name string) error {
+	return r.deleteImpl(name)
+}
+
+func (r *ResourceClient) patchInto(name string, patchType PatchType, data []byte, target interface{}) error {
+	_, err := r.client.rawRequestWithContentType("PATCH", r.path(name), string(patchType), bytes.NewReader(data), target)
+	return err
+}
+
+// Patch partially updates the named object; see PatchType for the supported
+// patch body formats.
+func (r *ResourceClient) Patch(name string, patchType PatchType, data []byte) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := r.patchInto(name, patchType, data, &result)
+	return result, err
+}
+
+// PatchType selects how a Patch body should be interpreted by the server.
+type PatchType string
+
+const (
+	JSONPatchType           PatchType = "application/json-patch+json"
+	MergePatchType          PatchType = "application/merge-patch+json"
+	StrategicMergePatchType PatchType = "application/strategic-merge-patch+json"
+)
+
+// APIResource describes a single resource kind the server exposes under a
+// given API path, e.g. {"name": "pods", "kind": "Pod", "namespaced": true}.
+type APIResource struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// APIResourceList is the index payload the API server returns when queried
+// for the resources it supports at a given API path.
+type APIResourceList struct {
+	Kind         string        `json:"kind"`
+	GroupVersion string        `json:"groupVersion"`
+	Resources    []APIResource `json:"resources"`
+}
+
+// Discover queries the server's resource index and returns the kinds it
+// supports (the path segments Resource expects, e.g. "pods", "services"),
+// so tools can be written against kinds this package has no typed methods
+// for without recompiling when the server adds new ones.
+func (c *Client) Discover() ([]string, error) {
+	var list APIResourceList
+	_, err := c.rawRequest("GET", "", nil, &list)
+	if err != nil {
+		return nil, err
+	}
+	kinds := make([]string, 0, len(list.Resources))
+	for _, resource := range list.Resources {
+		kinds = append(kinds, resource.Name)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// apiResourceListFixture is a representative APIResourceList response for
+// the API root, the shape Discover decodes against.
+const apiResourceListFixture = `{
+	"kind": "APIResourceList",
+	"groupVersion": "v1beta1",
+	"resources": [
+		{"name": "pods", "kind": "Pod", "namespaced": true},
+		{"name": "services", "kind": "Service", "namespaced": true},
+		{"name": "replicationControllers", "kind": "ReplicationController", "namespaced": true}
+	]
+}`
+
+func TestDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(apiResourceListFixture))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	kinds, err := client.Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	want := []string{"pods", "services", "replicationControllers"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Discover() = %v, want %v", kinds, want)
+	}
+}
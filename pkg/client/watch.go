@@ -0,0 +1,287 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// EventType is the type of change a watch Event describes.
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
+)
+
+// statusReasonExpired is the api.Status.Reason the server sends when the
+// resourceVersion a watch was started from has fallen out of its history.
+const statusReasonExpired = "Expired"
+
+// watchRetryInterval is how long WatchPods/WatchServices/WatchReplicationControllers
+// wait before reconnecting after a transient network error.
+const watchRetryInterval = 1 * time.Second
+
+// ErrResourceVersionTooOld is delivered as the Object of an Error event when
+// the server can no longer satisfy a watch from the requested
+// resourceVersion. Callers should relist and restart the watch from the
+// resourceVersion the list returns.
+var ErrResourceVersionTooOld = errors.New("client: resourceVersion too old, relist required")
+
+// Event is a single change notification from a watch. Object holds the
+// decoded resource (api.Pod, api.Service, or api.ReplicationController,
+// matching whichever Watch* call produced this Event) for Added, Modified
+// and Deleted events. For a terminal Error event, Object is either
+// ErrResourceVersionTooOld or a *StatusError describing why the server
+// ended the watch.
+type Event struct {
+	Type   EventType
+	Object interface{}
+}
+
+// watchFrame is the wire format the server streams, one JSON value per line.
+type watchFrame struct {
+	Type   EventType       `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// objectMeta pulls just the resourceVersion out of a frame's Object so the
+// watch loop can pick up where it left off after a reconnect, without
+// depending on the full shape of whichever typed object it is.
+type objectMeta struct {
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// watchCloser stops a watch loop. Close is safe to call more than once.
+type watchCloser struct {
+	once sync.Once
+	stop chan struct{}
+}
+
+func (w *watchCloser) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	return nil
+}
+
+// WatchPods streams Added/Modified/Deleted events for pods matching
+// selector, starting after resourceVersion (pass "" to start from "now").
+// The returned channel is closed when the Closer is closed or the watch
+// ends with an Error event; transient network errors are retried
+// automatically from the last observed resourceVersion.
+func (c *Client) WatchPods(selector map[string]string, resourceVersion string) (<-chan Event, io.Closer, error) {
+	return c.watch("pods", selector, resourceVersion, decodePodObject)
+}
+
+// WatchServices is WatchPods for services.
+func (c *Client) WatchServices(selector map[string]string, resourceVersion string) (<-chan Event, io.Closer, error) {
+	return c.watch("services", selector, resourceVersion, decodeServiceObject)
+}
+
+// WatchReplicationControllers is WatchPods for replication controllers.
+func (c *Client) WatchReplicationControllers(selector map[string]string, resourceVersion string) (<-chan Event, io.Closer, error) {
+	return c.watch("replicationControllers", selector, resourceVersion, decodeReplicationControllerObject)
+}
+
+func decodePodObject(raw json.RawMessage) (interface{}, error) {
+	var pod api.Pod
+	err := json.Unmarshal(raw, &pod)
+	return pod, err
+}
+
+func decodeServiceObject(raw json.RawMessage) (interface{}, error) {
+	var svc api.Service
+	err := json.Unmarshal(raw, &svc)
+	return svc, err
+}
+
+func decodeReplicationControllerObject(raw json.RawMessage) (interface{}, error) {
+	var controller api.ReplicationController
+	err := json.Unmarshal(raw, &controller)
+	return controller, err
+}
+
+// watch starts the reconnecting watch loop for resource in the background
+// and returns the channel of Events and a Closer to stop it. The returned
+// error is always nil: every connection attempt, the first included, is made
+// by the loop, which retries any failure to open the watch.
+func (c *Client) watch(resource string, selector map[string]string, resourceVersion string, decode func(json.RawMessage) (interface{}, error)) (<-chan Event, io.Closer, error) {
+	events := make(chan Event)
+	closer := &watchCloser{stop: make(chan struct{})}
+	go c.watchLoop(resource, selector, resourceVersion, decode, events, closer.stop)
+	return events, closer, nil
+}
+
+func (c *Client) watchLoop(resource string, selector map[string]string, resourceVersion string, decode func(json.RawMessage) (interface{}, error), events chan<- Event, stopCh <-chan struct{}) {
+	defer close(events)
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		body, closeBody, err := c.openWatch(resource, selector, resourceVersion)
+		if err != nil {
+			if waitOrStop(stopCh, watchRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		// consumeWatch blocks in Decode until the server sends a frame, so
+		// close the body as soon as the Closer fires to unblock it.
+		consumed := make(chan struct{})
+		go func() {
+			select {
+			case <-stopCh:
+				closeBody()
+			case <-consumed:
+			}
+		}()
+		resourceVersion, err = c.consumeWatch(body, decode, resourceVersion, events, stopCh)
+		close(consumed)
+		closeBody()
+		if err == errWatchStopped {
+			return
+		}
+		if err == ErrResourceVersionTooOld {
+			select {
+			case events <- Event{Type: Error, Object: ErrResourceVersionTooOld}:
+			case <-stopCh:
+			}
+			return
+		}
+		if statusErr, ok := err.(*StatusError); ok {
+			select {
+			case events <- Event{Type: Error, Object: statusErr}:
+			case <-stopCh:
+			}
+			return
+		}
+		// Any other error (EOF, connection reset, ...) is transient: wait and
+		// reconnect from the last observed resourceVersion. Without this, a
+		// server that accepts the watch and immediately closes the stream
+		// turns into a tight reconnect loop.
+		if waitOrStop(stopCh, watchRetryInterval) {
+			return
+		}
+	}
+}
+
+// errWatchStopped is a private sentinel distinguishing "the caller closed
+// us" from a reconnectable error; it never escapes this file.
+var errWatchStopped = errors.New("client: watch stopped")
+
+func (c *Client) consumeWatch(body io.ReadCloser, decode func(json.RawMessage) (interface{}, error), resourceVersion string, events chan<- Event, stopCh <-chan struct{}) (string, error) {
+	decoder := json.NewDecoder(body)
+	for {
+		var frame watchFrame
+		if err := decoder.Decode(&frame); err != nil {
+			return resourceVersion, err
+		}
+
+		if frame.Type == Error {
+			status, ok := decodeStatus(frame.Object)
+			if !ok {
+				continue
+			}
+			if status.Reason == statusReasonExpired {
+				return resourceVersion, ErrResourceVersionTooOld
+			}
+			return resourceVersion, &StatusError{Status: status}
+		}
+
+		object, err := decode(frame.Object)
+		if err != nil {
+			continue
+		}
+
+		var meta objectMeta
+		if json.Unmarshal(frame.Object, &meta) == nil && meta.ResourceVersion != "" {
+			resourceVersion = meta.ResourceVersion
+		}
+
+		select {
+		case events <- Event{Type: frame.Type, Object: object}:
+		case <-stopCh:
+			return resourceVersion, errWatchStopped
+		}
+	}
+}
+
+func (c *Client) openWatch(resource string, selector map[string]string, resourceVersion string) (io.ReadCloser, func(), error) {
+	path := resource + "?watch=1"
+	if resourceVersion != "" {
+		path += "&resourceVersion=" + url.QueryEscape(resourceVersion)
+	}
+	if selector != nil && len(selector) > 0 {
+		path += "&labels=" + EncodeSelector(selector)
+	}
+
+	request, err := http.NewRequest("GET", c.makeURL(path), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.authenticate(request)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.TokenFile != "" {
+		response.Body.Close()
+		if refreshErr := c.auth.refreshToken(); refreshErr != nil {
+			return nil, nil, fmt.Errorf("client: failed to refresh token after 401: %v", refreshErr)
+		}
+		c.authenticate(request)
+		response, err = c.httpClient.Do(request)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode > http.StatusPartialContent {
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		return nil, nil, fmt.Errorf("watch [%s] failed (%d) %s: %s", path, response.StatusCode, response.Status, string(body))
+	}
+	return response.Body, func() { response.Body.Close() }, nil
+}
+
+// waitOrStop waits for d, or returns true early if stopCh fires first.
+func waitOrStop(stopCh <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stopCh:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestConsumeWatchSurfacesNonExpiredErrorFrame guards against a regression
+// where an Error frame whose Reason wasn't "Expired" (e.g. "Forbidden", if
+// the server's RBAC policy changed mid-watch) was silently swallowed
+// instead of being reported to the caller.
+func TestConsumeWatchSurfacesNonExpiredErrorFrame(t *testing.T) {
+	body := ioutil.NopCloser(strings.NewReader(
+		`{"type":"ERROR","object":{"kind":"Status","status":"Failure","message":"watch forbidden","reason":"Forbidden","code":403}}` + "\n",
+	))
+
+	c := &Client{}
+	_, err := c.consumeWatch(body, decodePodObject, "", make(chan Event), make(chan struct{}))
+
+	statusErr, ok := err.(*StatusError)
+	if !ok {
+		t.Fatalf("consumeWatch err = %v (%T), want *StatusError", err, err)
+	}
+	if statusErr.Status.Reason != "Forbidden" {
+		t.Errorf("got reason %q, want %q", statusErr.Status.Reason, "Forbidden")
+	}
+}
+
+// TestOpenWatchRefreshesTokenOn401 guards against a regression where a
+// watch that hit a 401 mid-stream (e.g. an expired in-cluster projected
+// token) was retried forever without ever refreshing the token, unlike
+// doRequestWithContext's handling of the same case.
+func TestOpenWatchRefreshesTokenOn401(t *testing.T) {
+	var attempts int
+	var gotAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tokenFile, cleanup := writeTempTokenFile(t, "refreshed-token")
+	defer cleanup()
+
+	c := &Client{
+		host:       server.URL,
+		auth:       &AuthInfo{Token: "stale-token", TokenFile: tokenFile},
+		httpClient: server.Client(),
+	}
+
+	body, closeBody, err := c.openWatch("pods", nil, "")
+	if err != nil {
+		t.Fatalf("openWatch: %v", err)
+	}
+	defer closeBody()
+
+	if attempts != 2 {
+		t.Fatalf("got %d attempts, want 2 (one 401, one retry)", attempts)
+	}
+	if gotAuth[0] != "Bearer stale-token" || gotAuth[1] != "Bearer refreshed-token" {
+		t.Errorf("got auth headers %v, want [Bearer stale-token Bearer refreshed-token]", gotAuth)
+	}
+	body.Close()
+}